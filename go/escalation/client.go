@@ -69,6 +69,63 @@ func WithBackoff(strategy BackoffStrategy) ClientOption {
 	}
 }
 
+// WithTokenBucket gates retries through bucket in addition to the backoff
+// strategy, so a fleet of goroutines sharing bucket can't collectively
+// overwhelm a struggling downstream service. Pass the same *TokenBucket to
+// multiple clients to share one budget across them.
+func WithTokenBucket(bucket *TokenBucket) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.TokenBucket = bucket
+	}
+}
+
+// WithRetryClassifier overrides the default status-code-based retry
+// classification with classifier, taking full control of which attempts
+// are retried and what delay to honor.
+func WithRetryClassifier(classifier RetryClassifier) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.Classifier = classifier
+	}
+}
+
+// WithThrottleDelay sets a backoff envelope used instead of the normal
+// BaseDelay/MaxDelay when an attempt is throttled (HTTP 429, or any
+// response carrying a Retry-After header). Being told to slow down
+// usually warrants a much longer wait than an ordinary transient 5xx.
+func WithThrottleDelay(base, max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.ThrottleBaseDelay = base
+		c.retryConfig.ThrottleMaxDelay = max
+	}
+}
+
+// WithTryTimeout bounds each individual retry attempt with its own
+// context.WithTimeout, so a single slow try cannot consume the entire
+// retry budget. See RetryConfig.TryTimeout.
+func WithTryTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.TryTimeout = timeout
+	}
+}
+
+// WithOnRetry sets a callback invoked immediately before retryDo sleeps
+// between attempts, e.g. to drive a Prometheus counter or an OpenTelemetry
+// span per attempt. See RetryConfig.OnRetry. WithSlogLogger is a
+// ready-made implementation if structured logging is all that's needed.
+func WithOnRetry(onRetry func(attempt int, delay time.Duration, statusCode int, err error)) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.OnRetry = onRetry
+	}
+}
+
+// WithOnGiveUp sets a callback invoked immediately before retryDo returns a
+// terminal error. See RetryConfig.OnGiveUp.
+func WithOnGiveUp(onGiveUp func(attempts int, err error)) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.OnGiveUp = onGiveUp
+	}
+}
+
 // WithHTTPClient replaces the default http.Client.
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
@@ -78,16 +135,18 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 
 // Client is the main client for the Escalation Engine API.
 type Client struct {
-	apiKey      string
-	baseURL     string
-	httpClient  *http.Client
-	retryConfig RetryConfig
+	secretProvider SecretProvider
+	baseURL        string
+	httpClient     *http.Client
+	retryConfig    RetryConfig
 }
 
 // NewClient creates a new Escalation Engine client.
 //
 // The apiKey is required and can be obtained from the Escalation Engine dashboard.
-// Use functional options to customize the client behavior.
+// It is wrapped in a StaticSecretProvider; pass WithSecretProvider to resolve
+// credentials from an external store (environment variables, Vault, etc.)
+// instead. Use functional options to customize the client behavior.
 //
 // Example:
 //
@@ -97,8 +156,8 @@ type Client struct {
 //	)
 func NewClient(apiKey string, opts ...ClientOption) *Client {
 	c := &Client{
-		apiKey:  apiKey,
-		baseURL: defaultBaseURL,
+		secretProvider: &StaticSecretProvider{APIKey: apiKey},
+		baseURL:        defaultBaseURL,
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
@@ -289,7 +348,7 @@ func GenerateIdempotencyKey(namespace string, parts ...string) string {
 
 // doRequest executes an HTTP request with retry logic.
 func (c *Client) doRequest(ctx context.Context, method, path string, body []byte, idempotencyKey string) ([]byte, error) {
-	return retryDo(ctx, c.retryConfig, func(attempt int) ([]byte, int, time.Duration, error) {
+	return retryDo(ctx, c.retryConfig, func(attemptCtx context.Context, attempt int) ([]byte, int, time.Duration, error) {
 		reqURL := c.baseURL + path
 
 		var bodyReader io.Reader
@@ -297,12 +356,17 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body []byte
 			bodyReader = bytes.NewReader(body)
 		}
 
-		req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
+		req, err := http.NewRequestWithContext(attemptCtx, method, reqURL, bodyReader)
 		if err != nil {
 			return nil, 0, 0, fmt.Errorf("escalation: failed to create request: %w", err)
 		}
 
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		apiKey, err := c.secretProvider.GetAPIKey(attemptCtx)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("escalation: failed to resolve API key: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+apiKey)
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Accept", "application/json")
 		req.Header.Set("User-Agent", userAgent)
@@ -0,0 +1,338 @@
+// Package conformance runs the escalation SDK against a shared corpus of
+// JSON test vectors under testdata/vectors/. The same vectors are meant to
+// be consumed by the backend and other-language SDKs, so that signature
+// format drift, error mapping drift, and retry-classification drift
+// between implementations is caught in CI rather than in production.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prime001/humanrail-sdk/go/escalation"
+)
+
+var update = flag.Bool("update", false, "update conformance vector expected output in place")
+
+// VectorsRef returns the pinned test-vector corpus revision from the
+// ESCALATION_VECTORS_REF environment variable, or "" if unset. CI sets
+// this so a run can be pointed at a specific commit of a shared vectors
+// corpus instead of whatever is checked into this repo.
+func VectorsRef() string {
+	return os.Getenv("ESCALATION_VECTORS_REF")
+}
+
+// Vector is one JSON test vector: a named input/expected-output pair
+// dispatched by Kind.
+type Vector struct {
+	ID          string          `json:"id"`
+	Description string          `json:"description"`
+	Kind        string          `json:"kind"`
+	Input       json.RawMessage `json:"input"`
+	Expected    json.RawMessage `json:"expected"`
+}
+
+// Run walks dir for *.json vector files and runs each one as a subtest,
+// dispatching by the vector's "kind" field:
+//
+//	webhook_verify  - VerifyWebhookSignatureMulti
+//	error_map       - HTTP status/body -> SDK error type mapping
+//	idempotency_key - GenerateIdempotencyKey
+//	retry_classify  - retry/give-up decisions for a status code
+//	task_roundtrip  - Task JSON marshal/unmarshal fidelity
+//
+// Pass -update (go test's standard flag convention) to rewrite each
+// vector's "expected" field with the actual result instead of failing.
+func Run(t *testing.T, dir string) {
+	t.Helper()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		var v Vector
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		t.Run(v.ID, func(t *testing.T) {
+			runVector(t, path, raw, v)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("conformance: failed to walk %s: %v", dir, err)
+	}
+}
+
+func runVector(t *testing.T, path string, raw []byte, v Vector) {
+	t.Helper()
+
+	var (
+		actual any
+		err    error
+	)
+
+	switch v.Kind {
+	case "webhook_verify":
+		actual, err = runWebhookVerify(v.Input)
+	case "idempotency_key":
+		actual, err = runIdempotencyKey(v.Input)
+	case "task_roundtrip":
+		actual, err = runTaskRoundtrip(v.Input)
+	case "error_map":
+		actual, err = runErrorMap(v.Input)
+	case "retry_classify":
+		actual, err = runRetryClassify(v.Input)
+	default:
+		t.Fatalf("%s: unknown vector kind %q", path, v.Kind)
+		return
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	if *update {
+		updateVector(t, path, raw, actual)
+		return
+	}
+
+	var expected any
+	if err := json.Unmarshal(v.Expected, &expected); err != nil {
+		t.Fatalf("%s: failed to unmarshal expected: %v", path, err)
+	}
+
+	// Round-trip actual through JSON so map[string]any/struct comparisons
+	// line up with the plain-JSON shape of expected.
+	actualJSON, err := json.Marshal(actual)
+	if err != nil {
+		t.Fatalf("%s: failed to marshal actual result: %v", path, err)
+	}
+	var actualNorm any
+	if err := json.Unmarshal(actualJSON, &actualNorm); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	if !reflect.DeepEqual(actualNorm, expected) {
+		t.Errorf("%s (%s): got %s, want %s", v.ID, v.Description, actualJSON, v.Expected)
+	}
+}
+
+func updateVector(t *testing.T, path string, raw []byte, actual any) {
+	t.Helper()
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	actualJSON, err := json.MarshalIndent(actual, "", "  ")
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	doc["expected"] = actualJSON
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0o644); err != nil {
+		t.Fatalf("%s: failed to write updated vector: %v", path, err)
+	}
+}
+
+// --- webhook_verify ---
+
+type webhookVerifyInput struct {
+	Payload          string            `json:"payload"`
+	Signature        string            `json:"signature"`
+	Secrets          map[string]string `json:"secrets"`
+	ToleranceSeconds int               `json:"toleranceSeconds"`
+	AcceptedSchemes  []string          `json:"acceptedSchemes"`
+	RequireKeyID     bool              `json:"requireKeyId"`
+	Method           string            `json:"method"`
+	Path             string            `json:"path"`
+}
+
+type webhookVerifyResult struct {
+	OK     bool   `json:"ok"`
+	KeyID  string `json:"keyId,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+func runWebhookVerify(input json.RawMessage) (any, error) {
+	var in webhookVerifyInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, err
+	}
+
+	keyID, scheme, err := escalation.VerifyWebhookSignatureMulti(in.Payload, in.Signature, in.Secrets, escalation.VerifyOptions{
+		Tolerance:       time.Duration(in.ToleranceSeconds) * time.Second,
+		AcceptedSchemes: in.AcceptedSchemes,
+		RequireKeyID:    in.RequireKeyID,
+		Method:          in.Method,
+		Path:            in.Path,
+	})
+	if err != nil {
+		return webhookVerifyResult{OK: false}, nil
+	}
+	return webhookVerifyResult{OK: true, KeyID: keyID, Scheme: scheme}, nil
+}
+
+// --- idempotency_key ---
+
+type idempotencyKeyInput struct {
+	Namespace string   `json:"namespace"`
+	Parts     []string `json:"parts"`
+}
+
+type idempotencyKeyResult struct {
+	Key string `json:"key"`
+}
+
+func runIdempotencyKey(input json.RawMessage) (any, error) {
+	var in idempotencyKeyInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, err
+	}
+	return idempotencyKeyResult{Key: escalation.GenerateIdempotencyKey(in.Namespace, in.Parts...)}, nil
+}
+
+// --- task_roundtrip ---
+
+func runTaskRoundtrip(input json.RawMessage) (any, error) {
+	var task escalation.Task
+	if err := json.Unmarshal(input, &task); err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// --- error_map ---
+
+type errorMapInput struct {
+	StatusCode int             `json:"statusCode"`
+	Body       json.RawMessage `json:"body"`
+	RetryAfter string          `json:"retryAfter,omitempty"`
+}
+
+type errorMapResult struct {
+	Type string `json:"type"`
+}
+
+func runErrorMap(input json.RawMessage) (any, error) {
+	var in errorMapInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, err
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if in.RetryAfter != "" {
+			w.Header().Set("Retry-After", in.RetryAfter)
+		}
+		w.WriteHeader(in.StatusCode)
+		if len(in.Body) > 0 {
+			w.Write(in.Body)
+		}
+	}))
+	defer server.Close()
+
+	client := escalation.NewClient("ek_test_conformance",
+		escalation.WithBaseURL(server.URL),
+		escalation.WithMaxRetries(0),
+	)
+
+	_, err := client.GetTask(context.Background(), "task_123")
+	if err == nil {
+		return errorMapResult{Type: "nil"}, nil
+	}
+	return errorMapResult{Type: errorTypeName(err)}, nil
+}
+
+func errorTypeName(err error) string {
+	switch err.(type) {
+	case *escalation.AuthenticationError:
+		return "AuthenticationError"
+	case *escalation.AuthorizationError:
+		return "AuthorizationError"
+	case *escalation.TaskNotFoundError:
+		return "TaskNotFoundError"
+	case *escalation.ConflictError:
+		return "ConflictError"
+	case *escalation.ValidationError:
+		return "ValidationError"
+	case *escalation.RateLimitError:
+		return "RateLimitError"
+	case *escalation.ServerError:
+		return "ServerError"
+	case *escalation.EscalationError:
+		return "EscalationError"
+	default:
+		return reflect.TypeOf(err).String()
+	}
+}
+
+// --- retry_classify ---
+
+type retryClassifyInput struct {
+	StatusCode int `json:"statusCode"`
+	MaxRetries int `json:"maxRetries"`
+}
+
+type retryClassifyResult struct {
+	RequestCount int `json:"requestCount"`
+}
+
+func runRetryClassify(input json.RawMessage) (any, error) {
+	var in retryClassifyInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, err
+	}
+
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(in.StatusCode)
+	}))
+	defer server.Close()
+
+	client := escalation.NewClient("ek_test_conformance",
+		escalation.WithBaseURL(server.URL),
+		escalation.WithMaxRetries(in.MaxRetries),
+		escalation.WithBackoff(escalation.BackoffNone),
+	)
+
+	_, _ = client.GetTask(context.Background(), "task_123")
+
+	return retryClassifyResult{RequestCount: int(atomic.LoadInt32(&count))}, nil
+}
@@ -0,0 +1,14 @@
+package conformance_test
+
+import (
+	"testing"
+
+	"github.com/prime001/humanrail-sdk/go/escalation/conformance"
+)
+
+// TestVectors runs every JSON test vector under testdata/vectors/ so
+// signature format drift, error mapping drift, and retry-classification
+// drift are caught in CI rather than in production.
+func TestVectors(t *testing.T) {
+	conformance.Run(t, "../../../testdata/vectors")
+}
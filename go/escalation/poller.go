@@ -0,0 +1,362 @@
+package escalation
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Poller tracks a long-running operation's progress and lets the caller
+// resume polling across process restarts via a compact resume token,
+// modeled on Azure autorest's async operation poller. Create one with
+// Client.NewTaskPoller or Client.ResumeTaskPoller rather than constructing
+// it directly.
+type Poller[T any] struct {
+	client *Client
+	taskID string
+	fetch  func(ctx context.Context, ifNoneMatch string) (*T, string, bool, error)
+	isDone func(*T) bool
+	status func(*T) string
+
+	mu               sync.Mutex
+	lastETag         string
+	lastStatus       string
+	lastPolledAt     time.Time
+	nextDelay        time.Duration
+	result           *T
+	done             bool
+	needsResultFetch bool
+}
+
+// NewTaskPoller creates a Poller for taskID, suitable for handing off to a
+// workflow orchestrator (Temporal, a durable queue worker) that wants to
+// drive polling itself instead of blocking in WaitForCompletion.
+func (c *Client) NewTaskPoller(taskID string) *Poller[Task] {
+	return &Poller[Task]{
+		client: c,
+		taskID: taskID,
+		fetch: func(ctx context.Context, ifNoneMatch string) (*Task, string, bool, error) {
+			return c.pollTaskOnce(ctx, taskID, ifNoneMatch)
+		},
+		isDone: func(t *Task) bool { return t.Status.IsTerminal() },
+		status: func(t *Task) string { return string(t.Status) },
+	}
+}
+
+// resumeTokenPayload is the JSON shape encoded into a Poller resume token.
+type resumeTokenPayload struct {
+	TaskID       string        `json:"taskID"`
+	LastETag     string        `json:"lastETag"`
+	LastStatus   string        `json:"lastStatus"`
+	LastPolledAt time.Time     `json:"lastPolledAt"`
+	NextDelay    time.Duration `json:"nextDelay"`
+}
+
+// ResumeTaskPoller reconstructs a Poller from a token previously produced
+// by Poller.ResumeToken, so polling can continue in a different process
+// without losing exponential-backoff state or re-fetching from scratch.
+func (c *Client) ResumeTaskPoller(token string) (*Poller[Task], error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("escalation: invalid resume token: %w", err)
+	}
+
+	var payload resumeTokenPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("escalation: invalid resume token: %w", err)
+	}
+
+	p := c.NewTaskPoller(payload.TaskID)
+	p.lastETag = payload.LastETag
+	p.lastStatus = payload.LastStatus
+	p.lastPolledAt = payload.LastPolledAt
+	p.nextDelay = payload.NextDelay
+	p.done = TaskStatus(payload.LastStatus).IsTerminal()
+	// A resumed Poller never carries its last fetched snapshot (the token
+	// only stores status, not the full task), so even a poller resumed
+	// already-done needs one more real fetch before Result has anything to
+	// return.
+	p.needsResultFetch = p.done
+
+	return p, nil
+}
+
+// defaultPollInterval is the starting delay NextPollDelay grows from when a
+// Poller has no prior backoff state (a fresh Poller, or one resumed from a
+// token captured before its first successful poll).
+const defaultPollInterval = 2 * time.Second
+
+// Poll makes a single conditional GET against the underlying resource,
+// sending the last known ETag as If-None-Match so an unchanged task is
+// cheap on the server side. It returns done=true once the operation has
+// reached a terminal state. Poll is safe to call repeatedly from a
+// workflow step that may be retried or rescheduled between calls.
+//
+// A Poller resumed via Client.ResumeTaskPoller from a token captured after
+// the task had already reached a terminal state reports done=true but has
+// no cached snapshot to hand back from Result yet (the token stores status,
+// not the full task); Poll detects this and makes one unconditional fetch
+// to retrieve it before honoring the done shortcut on later calls.
+//
+// Each call that does not complete the operation advances the poller's
+// internal backoff delay (the first such call sets it to 2s, each later
+// one doubles it), capped at the client's configured MaxDelay; call
+// NextPollDelay to read it (e.g. to schedule the next activity in a
+// workflow orchestrator that drives polling itself rather than calling
+// PollUntilDone).
+func (p *Poller[T]) Poll(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	ifNoneMatch := p.lastETag
+	needsResultFetch := p.needsResultFetch
+	alreadyDone := p.done && !needsResultFetch
+	if needsResultFetch {
+		// Force an unconditional GET so the response body actually comes
+		// back instead of a 304 against our own stale ETag.
+		ifNoneMatch = ""
+	}
+	p.mu.Unlock()
+
+	if alreadyDone {
+		return true, nil
+	}
+
+	result, etag, notModified, err := p.fetch(ctx, ifNoneMatch)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastPolledAt = time.Now()
+	if etag != "" {
+		p.lastETag = etag
+	}
+
+	if notModified {
+		p.advanceBackoffLocked()
+		return p.done, nil
+	}
+
+	p.result = result
+	p.lastStatus = p.status(result)
+	p.needsResultFetch = false
+	if p.isDone(result) {
+		p.done = true
+	} else {
+		p.advanceBackoffLocked()
+	}
+
+	return p.done, nil
+}
+
+// advanceBackoffLocked doubles p.nextDelay, capped at the client's
+// configured MaxDelay (or defaultPollInterval*16 if unset). p.mu must
+// already be held.
+func (p *Poller[T]) advanceBackoffLocked() {
+	maxDelay := p.client.retryConfig.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultPollInterval * 16
+	}
+
+	if p.nextDelay <= 0 {
+		p.nextDelay = defaultPollInterval
+		return
+	}
+
+	next := p.nextDelay * 2
+	if next > maxDelay {
+		next = maxDelay
+	}
+	p.nextDelay = next
+}
+
+// NextPollDelay returns the delay to wait before the next call to Poll,
+// reflecting however many consecutive not-done polls have grown it so far.
+// It is the value persisted by ResumeToken, so a poller resumed in another
+// process continues growing its delay instead of restarting from scratch.
+func (p *Poller[T]) NextPollDelay() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.nextDelay <= 0 {
+		return defaultPollInterval
+	}
+	return p.nextDelay
+}
+
+// Result returns the most recently fetched snapshot. It returns an error
+// if no successful poll has completed yet.
+func (p *Poller[T]) Result(ctx context.Context) (*T, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.result == nil {
+		return nil, fmt.Errorf("escalation: poller has not completed a successful poll yet")
+	}
+	return p.result, nil
+}
+
+// PollUntilDone polls repeatedly until the operation reaches a terminal
+// state or opts.Timeout elapses. If opts.PollInterval is set, it is used as
+// a fixed delay between polls; otherwise PollUntilDone waits whatever Poll
+// grew NextPollDelay to after each not-done result, so repeated polling
+// backs off instead of hammering the server at a flat interval. If opts is
+// nil, defaults to a 10-minute timeout with that adaptive backoff,
+// starting at 2 seconds.
+func (p *Poller[T]) PollUntilDone(ctx context.Context, opts *WaitOptions) (*T, error) {
+	var fixedInterval time.Duration
+	timeout := 10 * time.Minute
+
+	if opts != nil {
+		if opts.PollInterval > 0 {
+			fixedInterval = opts.PollInterval
+		}
+		if opts.Timeout > 0 {
+			timeout = opts.Timeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		done, err := p.Poll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return p.Result(ctx)
+		}
+
+		delay := fixedInterval
+		if delay <= 0 {
+			delay = p.NextPollDelay()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &TimeoutError{
+				EscalationError: EscalationError{
+					Message: fmt.Sprintf("operation did not reach a terminal state within %s", timeout),
+				},
+				TimeoutSeconds: timeout.Seconds(),
+			}
+		case <-timer.C:
+		}
+	}
+}
+
+// ResumeToken returns a compact, base64-encoded snapshot of the poller's
+// state: task ID, last ETag, last known status, last poll time, and the
+// next backoff delay. Pass it to Client.ResumeTaskPoller to continue
+// polling in a different process.
+func (p *Poller[T]) ResumeToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	raw, err := json.Marshal(resumeTokenPayload{
+		TaskID:       p.taskID,
+		LastETag:     p.lastETag,
+		LastStatus:   p.lastStatus,
+		LastPolledAt: p.lastPolledAt,
+		NextDelay:    p.nextDelay,
+	})
+	if err != nil {
+		return "", fmt.Errorf("escalation: failed to marshal resume token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// pollTaskOnce fetches task taskID with an optional conditional GET,
+// sending ifNoneMatch as the If-None-Match header when set. When the
+// server responds 304 Not Modified, it returns notModified=true and a nil
+// task so the caller keeps its cached state.
+func (c *Client) pollTaskOnce(ctx context.Context, taskID, ifNoneMatch string) (*Task, string, bool, error) {
+	path := fmt.Sprintf("/tasks/%s", url.PathEscape(taskID))
+
+	var etag string
+	var notModified bool
+
+	body, err := retryDo(ctx, c.retryConfig, func(attemptCtx context.Context, attempt int) ([]byte, int, time.Duration, error) {
+		req, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("escalation: failed to create request: %w", err)
+		}
+
+		apiKey, err := c.secretProvider.GetAPIKey(attemptCtx)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("escalation: failed to resolve API key: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, 0, 0, &EscalationError{
+				Message: fmt.Sprintf("request to GET %s failed: %s", path, err.Error()),
+				Err:     err,
+			}
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, resp.StatusCode, 0, &EscalationError{
+				Message: fmt.Sprintf("failed to read response body: %s", err.Error()),
+				Err:     err,
+			}
+		}
+
+		if resp.StatusCode == http.StatusNotModified {
+			notModified = true
+			etag = resp.Header.Get("ETag")
+			return nil, resp.StatusCode, 0, nil
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			etag = resp.Header.Get("ETag")
+			return respBody, resp.StatusCode, 0, nil
+		}
+
+		requestID := resp.Header.Get("X-Request-Id")
+		var errorBody *APIErrorResponse
+		if err := json.Unmarshal(respBody, &errorBody); err != nil {
+			errorBody = nil
+		}
+
+		var retryAfter time.Duration
+		if ra := resp.Header.Get("Retry-After"); ra != "" {
+			if seconds, err := strconv.ParseFloat(ra, 64); err == nil {
+				retryAfter = time.Duration(seconds * float64(time.Second))
+			}
+		}
+
+		return nil, resp.StatusCode, retryAfter, buildAPIError(resp.StatusCode, errorBody, requestID, retryAfter.Seconds())
+	})
+	if err != nil {
+		return nil, "", false, err
+	}
+	if notModified {
+		return nil, etag, true, nil
+	}
+
+	var task Task
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, "", false, fmt.Errorf("escalation: failed to unmarshal response: %w", err)
+	}
+	return &task, etag, false, nil
+}
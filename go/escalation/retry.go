@@ -2,11 +2,22 @@ package escalation
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"math"
 	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
+// ErrRetryQuotaExceeded is returned (wrapped around the triggering error)
+// when RetryConfig.TokenBucket cannot acquire enough tokens for another
+// retry attempt, even though MaxRetries has not been exhausted. This
+// protects a downstream service from being hammered by many goroutines
+// that each individually think a retry is "cheap."
+var ErrRetryQuotaExceeded = errors.New("escalation: retry quota exceeded")
+
 // BackoffStrategy determines the delay pattern between retries.
 type BackoffStrategy string
 
@@ -17,8 +28,24 @@ const (
 	BackoffLinear BackoffStrategy = "linear"
 	// BackoffNone retries immediately without delay.
 	BackoffNone BackoffStrategy = "none"
+	// BackoffFullJitter randomizes the entire delay between 0 and the
+	// exponential cap (BaseDelay * 2^attempt, capped at MaxDelay) on each
+	// attempt. Unlike the partial jitter exponential/linear schemes
+	// above, it doesn't keep a predictable floor, which avoids
+	// synchronizing thundering herds under load.
+	BackoffFullJitter BackoffStrategy = "full-jitter"
+	// BackoffDecorrelatedJitter carries state between attempts: each
+	// delay is a random value between BaseDelay and 3x the previous
+	// delay, capped at MaxDelay.
+	BackoffDecorrelatedJitter BackoffStrategy = "decorrelated-jitter"
 )
 
+// retryState carries backoff state between attempts within a single
+// retryDo invocation. Only BackoffDecorrelatedJitter uses it today.
+type retryState struct {
+	prevDelay time.Duration
+}
+
 // RetryConfig configures the retry behavior.
 type RetryConfig struct {
 	// MaxRetries is the maximum number of retry attempts.
@@ -29,6 +56,204 @@ type RetryConfig struct {
 	BaseDelay time.Duration
 	// MaxDelay is the maximum delay cap.
 	MaxDelay time.Duration
+	// ThrottleBaseDelay and ThrottleMaxDelay set a separate backoff
+	// envelope for throttling responses (HTTP 429, or any response
+	// carrying a Retry-After header), mirroring the AWS SDK's
+	// MinThrottleDelay/MaxThrottleDelay split: being told to slow down is
+	// a stronger signal than a transient 5xx and usually warrants a much
+	// longer wait (e.g. 500ms-60s vs. the ordinary 1s-30s). A zero value
+	// falls back to BaseDelay/MaxDelay.
+	ThrottleBaseDelay time.Duration
+	ThrottleMaxDelay  time.Duration
+	// TokenBucket, if set, gates whether a retry is permitted at all, in
+	// addition to the backoff logic above. It is meant to be shared
+	// across many retryDo invocations (e.g. stored once on a Client) so
+	// a fleet of goroutines can't each decide a retry is "cheap" and
+	// collectively overwhelm a struggling downstream service. Nil
+	// disables token-bucket gating entirely.
+	TokenBucket *TokenBucket
+	// Classifier, if set, decides retry/give-up and delay override for
+	// every attempt, taking precedence over the default status-code-based
+	// rules and the Retryable/RetryAfter error interfaces.
+	Classifier RetryClassifier
+	// TryTimeout, if non-zero, bounds each individual attempt with its own
+	// context.WithTimeout derived from the retryDo-level context, matching
+	// the Azure blob retry policy pattern where the overall context governs
+	// the whole retry loop but a single slow try cannot consume the entire
+	// budget. A per-try timeout is treated as a retryable, statusCode-0
+	// error as long as the parent context is still alive.
+	TryTimeout time.Duration
+	// OnRetry, if set, is invoked immediately before retryDo sleeps between
+	// attempts, e.g. to increment a Prometheus counter per status code or
+	// start an OpenTelemetry span per attempt. It runs outside any
+	// critical-path lock, and a panic inside it is recovered so a broken
+	// callback cannot break the retry loop.
+	OnRetry func(attempt int, delay time.Duration, statusCode int, err error)
+	// OnGiveUp, if set, is invoked once, immediately before retryDo returns
+	// a terminal error (MaxRetries exhausted, a non-retryable result, or
+	// the token bucket refusing another attempt). It runs outside any
+	// critical-path lock, and a panic inside it is recovered.
+	OnGiveUp func(attempts int, err error)
+}
+
+// RetryEvent is a structured description of a single retry decision,
+// carrying the same fields passed to OnRetry plus a timestamp, for callers
+// that want to route retries through a single log/metrics sink rather than
+// closing over state in OnRetry itself.
+type RetryEvent struct {
+	Attempt    int
+	Delay      time.Duration
+	StatusCode int
+	Err        error
+	Time       time.Time
+}
+
+// WithSlogLogger returns a ClientOption that logs structured retry
+// decisions to l: one "escalation retry" record per retried attempt and one
+// "escalation retry give up" record whenever retryDo returns a terminal
+// error (retries exhausted, a non-retryable result, the token bucket
+// refusing another attempt, or ctx ending during a backoff sleep).
+func WithSlogLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.OnRetry = func(attempt int, delay time.Duration, statusCode int, err error) {
+			event := RetryEvent{Attempt: attempt, Delay: delay, StatusCode: statusCode, Err: err, Time: time.Now()}
+			l.Info("escalation retry",
+				"attempt", event.Attempt,
+				"delay", event.Delay,
+				"status_code", event.StatusCode,
+				"error", event.Err,
+			)
+		}
+		c.retryConfig.OnGiveUp = func(attempts int, err error) {
+			l.Warn("escalation retry give up",
+				"attempts", attempts,
+				"error", err,
+			)
+		}
+	}
+}
+
+// runOnRetry invokes config.OnRetry, if set, recovering from any panic so a
+// broken callback cannot break the retry loop.
+func runOnRetry(config RetryConfig, attempt int, delay time.Duration, statusCode int, err error) {
+	if config.OnRetry == nil {
+		return
+	}
+	defer func() { recover() }()
+	config.OnRetry(attempt, delay, statusCode, err)
+}
+
+// runOnGiveUp invokes config.OnGiveUp, if set, recovering from any panic so
+// a broken callback cannot break the retry loop.
+func runOnGiveUp(config RetryConfig, attempts int, err error) {
+	if config.OnGiveUp == nil {
+		return
+	}
+	defer func() { recover() }()
+	config.OnGiveUp(attempts, err)
+}
+
+// RetryClassifier decides whether an attempt's result should be retried
+// and, if so, what delay to honor instead of the normal backoff
+// calculation (return 0 to fall back to backoff). Set it on
+// RetryConfig.Classifier to override the default classification (HTTP
+// 429/5xx are retryable, a no-status-code error is a retryable network
+// failure unless ctx is already done).
+type RetryClassifier func(ctx context.Context, statusCode int, err error) (retry bool, retryAfter time.Duration)
+
+// Retryable may be implemented by a user-defined error type to mark it
+// retryable (or not) regardless of the default classification. It is
+// consulted when fn returns an error with no HTTP status code, so domain
+// errors (validation failures, business-logic rejections) that would
+// otherwise look like a retryable network error can opt out.
+type Retryable interface {
+	Retryable() bool
+}
+
+// RetryAfter may be implemented alongside Retryable to suggest how long
+// retryDo should wait before the next attempt.
+type RetryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// IsRetryable reports whether err (or an error it wraps) implements
+// Retryable and, if so, what it returns. It returns false for errors that
+// don't implement the interface.
+func IsRetryable(err error) bool {
+	var r Retryable
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return false
+}
+
+// RetryAfterFor returns the duration suggested by err (or an error it
+// wraps) if it implements RetryAfter, or 0 otherwise.
+func RetryAfterFor(err error) time.Duration {
+	var r RetryAfter
+	if errors.As(err, &r) {
+		return r.RetryAfter()
+	}
+	return 0
+}
+
+// TokenBucket gates retries with a client-side token bucket, modeled on
+// the AWS SDK v2 "standard" retry mode's adaptive rate limiting. Each
+// retry attempt must acquire tokens before it is permitted; a successful
+// call releases a token back into the bucket. It is safe for concurrent
+// use across many retryDo invocations sharing a single RetryConfig.
+type TokenBucket struct {
+	capacity    uint64
+	retryCost   uint64
+	timeoutCost uint64
+	tokens      uint64 // accessed atomically
+}
+
+// NewTokenBucket creates a TokenBucket with capacity tokens, starting
+// full. retryCost is deducted for a generic retryable error (e.g. an HTTP
+// 429/5xx); timeoutCost is deducted for a timeout or other no-status
+// network error, which is typically made more expensive since it gives
+// the downstream service no signal about whether backing off will help.
+func NewTokenBucket(capacity, retryCost, timeoutCost uint) *TokenBucket {
+	return &TokenBucket{
+		capacity:    uint64(capacity),
+		retryCost:   uint64(retryCost),
+		timeoutCost: uint64(timeoutCost),
+		tokens:      uint64(capacity),
+	}
+}
+
+// Available returns the number of tokens currently available, for
+// exporting as a metric.
+func (b *TokenBucket) Available() uint {
+	return uint(atomic.LoadUint64(&b.tokens))
+}
+
+// tryAcquire deducts cost tokens if available, returning false otherwise.
+func (b *TokenBucket) tryAcquire(cost uint64) bool {
+	for {
+		current := atomic.LoadUint64(&b.tokens)
+		if current < cost {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(&b.tokens, current, current-cost) {
+			return true
+		}
+	}
+}
+
+// release returns amount tokens to the bucket, capped at capacity.
+func (b *TokenBucket) release(amount uint64) {
+	for {
+		current := atomic.LoadUint64(&b.tokens)
+		next := current + amount
+		if next > b.capacity {
+			next = b.capacity
+		}
+		if atomic.CompareAndSwapUint64(&b.tokens, current, next) {
+			return
+		}
+	}
 }
 
 // DefaultRetryConfig returns a RetryConfig with sensible defaults.
@@ -50,78 +275,184 @@ func isRetryableStatusCode(statusCode int) bool {
 	return statusCode == 429 || (statusCode >= 500 && statusCode <= 599)
 }
 
-// calculateDelay computes the delay before the next retry attempt, with jitter.
-func calculateDelay(attempt int, config RetryConfig, retryAfter time.Duration) time.Duration {
+// classifyRetry decides whether the attempt that produced err (with
+// statusCode and any server-supplied retryAfter) should be retried, and
+// what delay override to honor. config.Classifier, if set, takes full
+// control. Otherwise a no-status-code error is checked against the
+// Retryable/RetryAfter interfaces before falling back to the default
+// status-code-based rules, so this matches pre-Classifier behavior when
+// nothing is configured.
+func classifyRetry(ctx context.Context, config RetryConfig, statusCode int, err error, retryAfter time.Duration) (bool, time.Duration) {
+	if config.Classifier != nil {
+		return config.Classifier(ctx, statusCode, err)
+	}
+
+	if statusCode > 0 {
+		return isRetryableStatusCode(statusCode), retryAfter
+	}
+
+	var r Retryable
+	if errors.As(err, &r) {
+		effective := retryAfter
+		if af := RetryAfterFor(err); af > 0 {
+			effective = af
+		}
+		return r.Retryable(), effective
+	}
+
+	// Network-level errors (no status code) are retryable; context
+	// errors are not.
+	return ctx.Err() == nil, retryAfter
+}
+
+// calculateDelay computes the delay before the next retry attempt. state
+// carries backoff history between attempts within one retryDo invocation
+// (needed by BackoffDecorrelatedJitter) and may be nil, in which case
+// decorrelated jitter behaves as if no prior attempt had been made.
+// isThrottle selects the ThrottleBaseDelay/ThrottleMaxDelay envelope
+// instead of BaseDelay/MaxDelay when those are configured.
+func calculateDelay(attempt int, config RetryConfig, retryAfter time.Duration, isThrottle bool, state *retryState) time.Duration {
+	baseDelay, maxDelay := config.BaseDelay, config.MaxDelay
+	if isThrottle {
+		if config.ThrottleBaseDelay > 0 {
+			baseDelay = config.ThrottleBaseDelay
+		}
+		if config.ThrottleMaxDelay > 0 {
+			maxDelay = config.ThrottleMaxDelay
+		}
+	}
+
 	if retryAfter > 0 {
-		if retryAfter > config.MaxDelay {
-			return config.MaxDelay
+		if retryAfter > maxDelay {
+			return maxDelay
 		}
 		return retryAfter
 	}
 
-	if config.Backoff == BackoffNone {
+	switch config.Backoff {
+	case BackoffNone:
 		return 0
-	}
 
-	var delay time.Duration
-	switch config.Backoff {
+	case BackoffFullJitter:
+		capDelay := time.Duration(float64(baseDelay) * math.Pow(2, float64(attempt)))
+		if capDelay > maxDelay {
+			capDelay = maxDelay
+		}
+		if capDelay <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(capDelay))) //nolint:gosec
+
+	case BackoffDecorrelatedJitter:
+		prev := baseDelay
+		if state != nil && state.prevDelay > 0 {
+			prev = state.prevDelay
+		}
+		upper := prev * 3
+		if upper <= baseDelay {
+			upper = baseDelay + 1
+		}
+		delay := baseDelay + time.Duration(rand.Int63n(int64(upper-baseDelay))) //nolint:gosec
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+		if state != nil {
+			state.prevDelay = delay
+		}
+		return delay
+
 	case BackoffLinear:
-		delay = config.BaseDelay * time.Duration(attempt+1)
+		return addPartialJitterCapped(baseDelay*time.Duration(attempt+1), maxDelay)
+
 	default: // exponential
-		delay = config.BaseDelay * time.Duration(math.Pow(2, float64(attempt)))
+		delay := baseDelay * time.Duration(math.Pow(2, float64(attempt)))
+		return addPartialJitterCapped(delay, maxDelay)
 	}
+}
 
-	// Add jitter: random value between 0 and 50% of the delay
+// addPartialJitterCapped adds up to 50% jitter to delay and caps the
+// result at maxDelay, matching the original exponential/linear backoff
+// behavior.
+func addPartialJitterCapped(delay, maxDelay time.Duration) time.Duration {
 	jitter := time.Duration(rand.Float64() * float64(delay) * 0.5) //nolint:gosec
 	delay += jitter
-
-	if delay > config.MaxDelay {
-		delay = config.MaxDelay
+	if delay > maxDelay {
+		delay = maxDelay
 	}
-
 	return delay
 }
 
-// retryDo executes fn with retry logic. fn should return the response body,
-// the HTTP status code, any Retry-After duration, and an error.
-// If the error is non-nil and the status code is retryable, it will retry.
-func retryDo(ctx context.Context, config RetryConfig, fn func(attempt int) ([]byte, int, time.Duration, error)) ([]byte, error) {
+// retryFunc is one retry attempt. ctx is the overall retryDo context unless
+// RetryConfig.TryTimeout is set, in which case it is a derived context
+// bounded to that single attempt. fn should return the response body, the
+// HTTP status code, any Retry-After duration, and an error.
+type retryFunc func(ctx context.Context, attempt int) ([]byte, int, time.Duration, error)
+
+// retryDo executes fn with retry logic. If the error is non-nil and
+// classifyRetry deems the attempt retryable, it retries, as long as
+// MaxRetries is not exhausted and RetryConfig.TokenBucket (if set) grants
+// the tokens for another attempt.
+func retryDo(ctx context.Context, config RetryConfig, fn retryFunc) ([]byte, error) {
 	var lastErr error
+	state := &retryState{}
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
-		body, statusCode, retryAfter, err := fn(attempt)
+		attemptCtx := ctx
+		var cancel context.CancelFunc
+		if config.TryTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, config.TryTimeout)
+		}
+		body, statusCode, retryAfter, err := fn(attemptCtx, attempt)
+		if cancel != nil {
+			cancel()
+		}
+
+		if err != nil && statusCode == 0 && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("escalation: attempt %d timed out after %s: %w", attempt, config.TryTimeout, context.DeadlineExceeded)
+		}
 
 		if err == nil {
+			if config.TokenBucket != nil {
+				config.TokenBucket.release(1)
+			}
 			return body, nil
 		}
 
 		lastErr = err
 
-		// Don't retry if it's not a retryable status code (and we have a status code)
-		if statusCode > 0 && !isRetryableStatusCode(statusCode) {
+		retry, effectiveRetryAfter := classifyRetry(ctx, config, statusCode, err, retryAfter)
+		if !retry {
+			runOnGiveUp(config, attempt+1, err)
 			return nil, err
 		}
 
 		// Don't retry on the last attempt
 		if attempt == config.MaxRetries {
+			runOnGiveUp(config, attempt+1, err)
 			return nil, err
 		}
 
-		// Don't retry if we don't have a status code and it's not a network error
-		if statusCode == 0 {
-			// Network-level errors (no status code) are retryable
-			// Context errors are not
-			if ctx.Err() != nil {
-				return nil, err
+		if config.TokenBucket != nil {
+			cost := config.TokenBucket.retryCost
+			if statusCode == 0 {
+				cost = config.TokenBucket.timeoutCost
+			}
+			if !config.TokenBucket.tryAcquire(cost) {
+				quotaErr := fmt.Errorf("%w: %w", ErrRetryQuotaExceeded, err)
+				runOnGiveUp(config, attempt+1, quotaErr)
+				return nil, quotaErr
 			}
 		}
 
-		delay := calculateDelay(attempt, config, retryAfter)
+		isThrottle := statusCode == 429 || effectiveRetryAfter > 0
+		delay := calculateDelay(attempt, config, effectiveRetryAfter, isThrottle, state)
+		runOnRetry(config, attempt, delay, statusCode, err)
 		if delay > 0 {
 			timer := time.NewTimer(delay)
 			select {
 			case <-ctx.Done():
 				timer.Stop()
+				runOnGiveUp(config, attempt+1, ctx.Err())
 				return nil, ctx.Err()
 			case <-timer.C:
 			}
@@ -0,0 +1,109 @@
+package escalation
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCalculateDelayFullJitterDistribution statistically verifies that
+// BackoffFullJitter spans the full [0, cap) range rather than clustering
+// near a fixed floor, and never exceeds MaxDelay.
+func TestCalculateDelayFullJitterDistribution(t *testing.T) {
+	config := RetryConfig{
+		Backoff:   BackoffFullJitter,
+		BaseDelay: 1 * time.Second,
+		MaxDelay:  10 * time.Second,
+	}
+
+	const attempt = 2 // cap = BaseDelay * 2^attempt = 4s
+	const samples = 2000
+	const wantCap = 4 * time.Second
+
+	var min, max, sum time.Duration
+	min = wantCap
+	for i := 0; i < samples; i++ {
+		delay := calculateDelay(attempt, config, 0, false, nil)
+		if delay < 0 || delay >= wantCap {
+			t.Fatalf("delay %s out of expected [0, %s) range", delay, wantCap)
+		}
+		if delay < min {
+			min = delay
+		}
+		if delay > max {
+			max = delay
+		}
+		sum += delay
+	}
+
+	mean := sum / samples
+	wantMean := wantCap / 2
+	tolerance := wantCap / 5 // 20% slack
+	if mean < wantMean-tolerance || mean > wantMean+tolerance {
+		t.Errorf("mean delay %s too far from expected midpoint %s (samples=%d, min=%s, max=%s)", mean, wantMean, samples, min, max)
+	}
+
+	// The distribution should actually spread across the range, not
+	// cluster near one end.
+	if max-min < wantCap/2 {
+		t.Errorf("full-jitter samples did not spread across [0, %s): min=%s max=%s", wantCap, min, max)
+	}
+}
+
+// TestCalculateDelayDecorrelatedJitterDistribution verifies that
+// BackoffDecorrelatedJitter stays within [BaseDelay, 3x previous delay]
+// (capped at MaxDelay) and that state.prevDelay is threaded across calls
+// so each delay is correlated with, not independent of, the last.
+func TestCalculateDelayDecorrelatedJitterDistribution(t *testing.T) {
+	config := RetryConfig{
+		Backoff:   BackoffDecorrelatedJitter,
+		BaseDelay: 1 * time.Second,
+		MaxDelay:  30 * time.Second,
+	}
+
+	state := &retryState{}
+	prev := config.BaseDelay
+	for attempt := 0; attempt < 50; attempt++ {
+		delay := calculateDelay(attempt, config, 0, false, state)
+
+		if delay < config.BaseDelay {
+			t.Fatalf("attempt %d: delay %s below BaseDelay %s", attempt, delay, config.BaseDelay)
+		}
+		if delay > config.MaxDelay {
+			t.Fatalf("attempt %d: delay %s above MaxDelay %s", attempt, delay, config.MaxDelay)
+		}
+
+		upper := prev * 3
+		if upper <= config.BaseDelay {
+			upper = config.BaseDelay + 1
+		}
+		if upper > config.MaxDelay {
+			upper = config.MaxDelay
+		}
+		if delay > upper {
+			t.Fatalf("attempt %d: delay %s exceeds decorrelated upper bound %s (prev=%s)", attempt, delay, upper, prev)
+		}
+
+		if state.prevDelay != delay {
+			t.Fatalf("attempt %d: retryState.prevDelay = %s, want %s threaded from this call", attempt, state.prevDelay, delay)
+		}
+
+		prev = delay
+	}
+}
+
+// TestCalculateDelayDecorrelatedJitterNilState verifies a nil state behaves
+// as if no prior attempt had been made, rather than panicking.
+func TestCalculateDelayDecorrelatedJitterNilState(t *testing.T) {
+	config := RetryConfig{
+		Backoff:   BackoffDecorrelatedJitter,
+		BaseDelay: 1 * time.Second,
+		MaxDelay:  30 * time.Second,
+	}
+
+	for i := 0; i < 100; i++ {
+		delay := calculateDelay(0, config, 0, false, nil)
+		if delay < config.BaseDelay || delay > config.MaxDelay {
+			t.Fatalf("delay %s out of [%s, %s] with nil state", delay, config.BaseDelay, config.MaxDelay)
+		}
+	}
+}
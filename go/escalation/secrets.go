@@ -0,0 +1,278 @@
+package escalation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// SecretProvider resolves credentials for the Escalation Engine client at
+// request time rather than having them baked into the client at
+// construction. This enables zero-downtime API key rotation and webhook
+// signing secrets managed by an external store such as Vault.
+type SecretProvider interface {
+	// GetAPIKey returns the bearer token to use for the next request.
+	GetAPIKey(ctx context.Context) (string, error)
+	// GetWebhookSecret returns the webhook signing secret identified by
+	// keyID. keyID is empty when the signature header carries no key
+	// identifier, in which case the provider should return its default
+	// secret.
+	GetWebhookSecret(ctx context.Context, keyID string) (string, error)
+}
+
+// WithSecretProvider configures the client to resolve its API key and
+// webhook secrets through provider instead of the static string passed to
+// NewClient.
+func WithSecretProvider(provider SecretProvider) ClientOption {
+	return func(c *Client) {
+		c.secretProvider = provider
+	}
+}
+
+// StaticSecretProvider is a SecretProvider backed by fixed, in-memory
+// values. NewClient wraps a plain API key string in one of these.
+type StaticSecretProvider struct {
+	// APIKey is the bearer token returned by GetAPIKey.
+	APIKey string
+	// WebhookSecrets maps a key ID to its signing secret. The empty key
+	// ID ("") is the default secret used when a signature header carries
+	// no kid= component.
+	WebhookSecrets map[string]string
+}
+
+// GetAPIKey implements SecretProvider.
+func (p *StaticSecretProvider) GetAPIKey(ctx context.Context) (string, error) {
+	return p.APIKey, nil
+}
+
+// GetWebhookSecret implements SecretProvider.
+func (p *StaticSecretProvider) GetWebhookSecret(ctx context.Context, keyID string) (string, error) {
+	if secret, ok := p.WebhookSecrets[keyID]; ok {
+		return secret, nil
+	}
+	return "", fmt.Errorf("escalation: no webhook secret registered for key id %q", keyID)
+}
+
+// EnvSecretProvider resolves the API key and webhook secrets from
+// environment variables, re-reading them on every call so a rotated value
+// takes effect without restarting the process.
+type EnvSecretProvider struct {
+	// APIKeyEnv is the environment variable holding the API key.
+	APIKeyEnv string
+	// WebhookSecretEnv maps a key ID to the environment variable holding
+	// its webhook secret. The empty key ID is the default secret.
+	WebhookSecretEnv map[string]string
+}
+
+// GetAPIKey implements SecretProvider.
+func (p *EnvSecretProvider) GetAPIKey(ctx context.Context) (string, error) {
+	v := os.Getenv(p.APIKeyEnv)
+	if v == "" {
+		return "", fmt.Errorf("escalation: environment variable %s is not set", p.APIKeyEnv)
+	}
+	return v, nil
+}
+
+// GetWebhookSecret implements SecretProvider.
+func (p *EnvSecretProvider) GetWebhookSecret(ctx context.Context, keyID string) (string, error) {
+	envVar, ok := p.WebhookSecretEnv[keyID]
+	if !ok {
+		return "", fmt.Errorf("escalation: no webhook secret env mapping for key id %q", keyID)
+	}
+	v := os.Getenv(envVar)
+	if v == "" {
+		return "", fmt.Errorf("escalation: environment variable %s is not set", envVar)
+	}
+	return v, nil
+}
+
+// VaultSecretProvider resolves the API key from a Vault KV v2 secrets
+// engine and keeps it fresh with a background renewer goroutine that
+// watches the lease's TTL, modeled on hashicorp/vault/api's Renewer.
+// Webhook secrets are resolved lazily per key ID from a sibling path and
+// cached.
+//
+// The renewer runs for the lifetime of the VaultSecretProvider itself, not
+// the lifetime of the ctx passed to NewVaultSecretProvider (which only
+// bounds the initial synchronous read) — cancelling that ctx after
+// construction does not stop it. Call Close when the provider is no longer
+// needed to stop the renewer goroutine; until Close is called, GetAPIKey
+// keeps serving the last value the renewer fetched even after the
+// construction-time ctx is long gone.
+type VaultSecretProvider struct {
+	client     *vaultapi.Client
+	apiKeyPath string
+
+	mu     sync.RWMutex
+	apiKey string
+
+	webhookSecrets sync.Map // keyID -> string
+
+	renewCtx    context.Context
+	renewCancel context.CancelFunc
+	renewerDone chan struct{}
+}
+
+// NewVaultSecretProvider creates a VaultSecretProvider that reads the API
+// key from the KV v2 data path apiKeyPath (e.g.
+// "secret/data/escalation/api-key") and, if the returned secret is
+// renewable, starts a renewer goroutine that refreshes it before expiry.
+//
+// ctx only bounds the initial read; the renewer goroutine runs on its own
+// internal context so a short-lived setup ctx (e.g. from
+// context.WithTimeout) doesn't kill background renewal the moment it
+// expires. Call (*VaultSecretProvider).Close to stop the renewer.
+func NewVaultSecretProvider(ctx context.Context, client *vaultapi.Client, apiKeyPath string) (*VaultSecretProvider, error) {
+	renewCtx, renewCancel := context.WithCancel(context.Background())
+
+	p := &VaultSecretProvider{
+		client:      client,
+		apiKeyPath:  apiKeyPath,
+		renewCtx:    renewCtx,
+		renewCancel: renewCancel,
+		renewerDone: make(chan struct{}),
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, apiKeyPath)
+	if err != nil {
+		renewCancel()
+		return nil, fmt.Errorf("escalation: vault read failed for %s: %w", apiKeyPath, err)
+	}
+	if err := p.applyAPIKeySecret(secret); err != nil {
+		renewCancel()
+		return nil, err
+	}
+
+	if secret.Renewable {
+		watcher, err := client.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{Secret: secret})
+		if err != nil {
+			renewCancel()
+			return nil, fmt.Errorf("escalation: failed to start vault renewer: %w", err)
+		}
+		go p.renewLoop(watcher)
+	} else {
+		renewCancel()
+		close(p.renewerDone)
+	}
+
+	return p, nil
+}
+
+// Close stops the background lease renewer and waits for its goroutine to
+// exit. GetAPIKey continues to serve the last value the renewer fetched,
+// but it will no longer be refreshed. Close is safe to call more than once.
+func (p *VaultSecretProvider) Close() {
+	p.renewCancel()
+	<-p.renewerDone
+}
+
+// renewLoop watches the API key lease and re-reads the secret whenever the
+// renewer gives up, so GetAPIKey picks up the fresh value. It runs until
+// p.renewCtx is cancelled by Close, independent of whatever ctx was passed
+// to NewVaultSecretProvider. If a re-read fails, returns a malformed
+// secret, or returns a non-renewable one, the loop exits and GetAPIKey
+// keeps serving the last value loaded, which is no longer refreshed.
+func (p *VaultSecretProvider) renewLoop(watcher *vaultapi.LifetimeWatcher) {
+	defer close(p.renewerDone)
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-p.renewCtx.Done():
+			return
+		case <-watcher.DoneCh():
+			secret, err := p.client.Logical().ReadWithContext(p.renewCtx, p.apiKeyPath)
+			if err != nil || p.applyAPIKeySecret(secret) != nil {
+				return
+			}
+			if !secret.Renewable {
+				return
+			}
+			next, err := p.client.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{Secret: secret})
+			if err != nil {
+				return
+			}
+			watcher = next
+			go watcher.Start()
+		case <-watcher.RenewCh():
+			// Lease renewed in place; the cached value is still valid.
+		}
+	}
+}
+
+func (p *VaultSecretProvider) applyAPIKeySecret(secret *vaultapi.Secret) error {
+	value, err := extractVaultKV2Value(secret)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.apiKey = value
+	p.mu.Unlock()
+	return nil
+}
+
+// GetAPIKey implements SecretProvider.
+func (p *VaultSecretProvider) GetAPIKey(ctx context.Context) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.apiKey == "" {
+		return "", fmt.Errorf("escalation: vault secret provider has no API key loaded")
+	}
+	return p.apiKey, nil
+}
+
+// GetWebhookSecret implements SecretProvider, resolving and caching the
+// webhook secret for keyID from Vault. An empty keyID resolves "default".
+func (p *VaultSecretProvider) GetWebhookSecret(ctx context.Context, keyID string) (string, error) {
+	if keyID == "" {
+		keyID = "default"
+	}
+	if v, ok := p.webhookSecrets.Load(keyID); ok {
+		return v.(string), nil
+	}
+
+	path := webhookSecretVaultPath(p.apiKeyPath, keyID)
+	secret, err := p.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("escalation: vault read failed for %s: %w", path, err)
+	}
+	value, err := extractVaultKV2Value(secret)
+	if err != nil {
+		return "", err
+	}
+
+	p.webhookSecrets.Store(keyID, value)
+	return value, nil
+}
+
+// webhookSecretVaultPath derives the webhook secret path for keyID as a
+// sibling of apiKeyPath's mount, e.g. "secret/data/webhook-secrets/<keyID>".
+func webhookSecretVaultPath(apiKeyPath, keyID string) string {
+	mount := apiKeyPath
+	if idx := strings.Index(apiKeyPath, "/data/"); idx >= 0 {
+		mount = apiKeyPath[:idx]
+	}
+	return fmt.Sprintf("%s/data/webhook-secrets/%s", mount, keyID)
+}
+
+// extractVaultKV2Value pulls the "value" string out of a Vault KV v2
+// response's nested data.data map.
+func extractVaultKV2Value(secret *vaultapi.Secret) (string, error) {
+	if secret == nil {
+		return "", fmt.Errorf("escalation: vault returned no secret")
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("escalation: unexpected vault KV v2 response shape")
+	}
+	value, ok := data["value"].(string)
+	if !ok {
+		return "", fmt.Errorf("escalation: vault secret missing string \"value\" field")
+	}
+	return value, nil
+}
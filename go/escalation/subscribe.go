@@ -0,0 +1,247 @@
+package escalation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamPath          = "/stream"
+	subscribeBufferSize = 256
+	streamPingInterval  = 20 * time.Second
+	streamPongWait      = 45 * time.Second
+	streamWriteWait     = 5 * time.Second
+)
+
+// ErrSubscriptionBufferFull is delivered on the error channel returned by
+// Subscribe when the caller is not draining events fast enough. The
+// triggering event is dropped rather than blocking the reader goroutine.
+var ErrSubscriptionBufferFull = errors.New("escalation: subscription buffer full, event dropped")
+
+// streamEnvelope is the JSON-RPC-style frame exchanged over the /v1/stream
+// WebSocket connection, modeled after JSON-RPC subscription servers.
+type streamEnvelope struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type subscribeParams struct {
+	SubscribeFilter
+	LastEventID string `json:"lastEventId,omitempty"`
+}
+
+// Subscribe opens a persistent WebSocket connection to the Escalation
+// Engine's event stream and returns a channel of task lifecycle events, a
+// channel of non-fatal errors, and an unsubscribe function.
+//
+// The connection authenticates with the client's bearer token during the
+// handshake, then sends a {"method":"subscribe","params":{...}} frame and
+// demultiplexes incoming {"method":"notification","params":{...}} frames
+// onto the returned event channel. Subscribe reconnects automatically using
+// the client's configured backoff strategy and resumes from the ID of the
+// last event received (including one dropped for a full buffer) so a
+// transient disconnect does not miss events.
+//
+// The event channel is bounded; if the caller falls behind, the incoming
+// event that doesn't fit is dropped (the buffer's existing contents are
+// left untouched and delivered in order) and ErrSubscriptionBufferFull is
+// sent on the error channel instead of blocking the reader goroutine. Call
+// the returned function to close the connection and stop reconnecting.
+func (c *Client) Subscribe(ctx context.Context, filter SubscribeFilter) (<-chan WebhookEvent, <-chan error, func() error) {
+	events := make(chan WebhookEvent, subscribeBufferSize)
+	errs := make(chan error, subscribeBufferSize)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	sub := &subscription{
+		client: c,
+		filter: filter,
+		events: events,
+		errs:   errs,
+	}
+
+	go func() {
+		defer close(done)
+		defer close(events)
+		defer close(errs)
+		sub.run(ctx)
+	}()
+
+	unsubscribe := func() error {
+		cancel()
+		<-done
+		return nil
+	}
+
+	return events, errs, unsubscribe
+}
+
+// subscription manages one logical Subscribe call across reconnects.
+type subscription struct {
+	client *Client
+	filter SubscribeFilter
+
+	events chan<- WebhookEvent
+	errs   chan<- error
+
+	mu          sync.Mutex
+	lastEventID string
+}
+
+// run dials, streams, and reconnects until ctx is cancelled.
+func (s *subscription) run(ctx context.Context) {
+	attempt := 0
+	backoff := &retryState{}
+	for {
+		err := s.connectAndStream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.sendErr(err)
+		}
+
+		delay := calculateDelay(attempt, s.client.retryConfig, 0, false, backoff)
+		attempt++
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *subscription) sendErr(err error) {
+	select {
+	case s.errs <- err:
+	default:
+	}
+}
+
+// connectAndStream dials a single WebSocket connection, subscribes, and
+// streams notifications until the connection drops or ctx is cancelled.
+func (s *subscription) connectAndStream(ctx context.Context) error {
+	wsURL := strings.Replace(s.client.baseURL, "http", "ws", 1) + streamPath
+
+	apiKey, err := s.client.secretProvider.GetAPIKey(ctx)
+	if err != nil {
+		return fmt.Errorf("escalation: failed to resolve API key: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+apiKey)
+	header.Set("User-Agent", userAgent)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		return fmt.Errorf("escalation: stream dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	lastEventID := s.lastEventID
+	s.mu.Unlock()
+
+	params, err := json.Marshal(subscribeParams{SubscribeFilter: s.filter, LastEventID: lastEventID})
+	if err != nil {
+		return fmt.Errorf("escalation: failed to marshal subscribe params: %w", err)
+	}
+	frame, err := json.Marshal(streamEnvelope{Method: "subscribe", Params: params})
+	if err != nil {
+		return fmt.Errorf("escalation: failed to marshal subscribe frame: %w", err)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+		return fmt.Errorf("escalation: failed to send subscribe frame: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		return nil
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.pingLoop(conn, stop)
+
+	// conn.ReadMessage below only notices ctx cancellation once it returns
+	// an error, which never happens on its own against a server that keeps
+	// pushing frames. Close the connection out from under it so Subscribe's
+	// returned unsubscribe function doesn't hang waiting for the read loop
+	// to unblock.
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("escalation: stream read failed: %w", err)
+		}
+
+		var env streamEnvelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			s.sendErr(fmt.Errorf("escalation: malformed stream frame: %w", err))
+			continue
+		}
+
+		if env.Method != "notification" {
+			continue
+		}
+
+		var event WebhookEvent
+		if err := json.Unmarshal(env.Params, &event); err != nil {
+			s.sendErr(fmt.Errorf("escalation: malformed notification payload: %w", err))
+			continue
+		}
+
+		s.mu.Lock()
+		s.lastEventID = event.ID
+		s.mu.Unlock()
+
+		select {
+		case s.events <- event:
+		default:
+			s.sendErr(ErrSubscriptionBufferFull)
+		}
+	}
+}
+
+// pingLoop sends periodic WebSocket pings to keep the connection alive and
+// detect a dead peer faster than the read deadline alone would.
+func (s *subscription) pingLoop(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -199,9 +199,11 @@ const (
 	WebhookEventTaskExpired   WebhookEventType = "task.expired"
 )
 
-// WebhookEvent is a webhook event delivered to the callback URL.
+// WebhookEvent is a webhook event delivered to the callback URL, or streamed
+// as a notification frame over a Subscribe connection.
 type WebhookEvent struct {
-	// ID is the unique event identifier.
+	// ID is the unique event identifier. Also used as the resumption cursor
+	// for Subscribe reconnects.
 	ID string `json:"id"`
 	// Type is the event type.
 	Type WebhookEventType `json:"type"`
@@ -211,6 +213,17 @@ type WebhookEvent struct {
 	Data Task `json:"data"`
 }
 
+// SubscribeFilter narrows which task lifecycle events a Subscribe call
+// receives. A nil or empty slice imposes no filter on that dimension.
+type SubscribeFilter struct {
+	// TaskIDs restricts the subscription to specific task IDs.
+	TaskIDs []string `json:"taskIds,omitempty"`
+	// TaskTypes restricts the subscription to specific task types.
+	TaskTypes []string `json:"taskTypes,omitempty"`
+	// EventTypes restricts the subscription to specific event types.
+	EventTypes []WebhookEventType `json:"eventTypes,omitempty"`
+}
+
 // APIErrorResponse is the error response body from the API.
 type APIErrorResponse struct {
 	Error struct {
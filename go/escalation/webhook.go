@@ -1,16 +1,30 @@
 package escalation
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Signature scheme identifiers recognized by VerifyWebhookSignatureMulti
+// and ConstructWebhookSignatureMulti.
+const (
+	// SchemeV1 signs "<timestamp>.<raw-body>". It requires the full body
+	// in memory to verify.
+	SchemeV1 = "v1"
+	// SchemeV2 signs "<timestamp>.<sha256-hex-of-body>.<method>.<path>",
+	// so large payloads can be verified while streaming the body through
+	// HashWebhookBody instead of buffering it.
+	SchemeV2 = "v2"
+)
+
 // VerifyWebhookSignature verifies the authenticity and freshness of an
 // Escalation Engine webhook event.
 //
@@ -77,6 +91,214 @@ func VerifyWebhookSignature(payload, signature, secret string, tolerance time.Du
 	return hmac.Equal(computedBytes, expectedBytes)
 }
 
+// VerifyWebhookSignatureWithProvider verifies a webhook event the same way
+// as VerifyWebhookSignature, but resolves the signing secret through
+// provider using the kid= component of the signature header instead of a
+// fixed secret string. This allows zero-downtime key rotation: the sender
+// can start signing with a new key ID while provider still recognizes
+// previously-registered ones until they are retired.
+func VerifyWebhookSignatureWithProvider(ctx context.Context, payload, signature string, provider SecretProvider, tolerance time.Duration) bool {
+	if payload == "" || signature == "" || provider == nil {
+		return false
+	}
+
+	keyID := sigComponent(signature, "kid=")
+	secret, err := provider.GetWebhookSecret(ctx, keyID)
+	if err != nil || secret == "" {
+		return false
+	}
+
+	return VerifyWebhookSignature(payload, signature, secret, tolerance)
+}
+
+// sigComponent extracts the value of a token with the given prefix (e.g.
+// "kid=") from a comma-separated signature header. Returns "" if absent.
+func sigComponent(signature, prefix string) string {
+	for _, part := range strings.Split(signature, ",") {
+		if strings.HasPrefix(part, prefix) {
+			return part[len(prefix):]
+		}
+	}
+	return ""
+}
+
+// VerifyOptions configures VerifyWebhookSignatureMulti and
+// VerifyWebhookSignatureMultiStream.
+type VerifyOptions struct {
+	// Tolerance is the maximum age of the signature. Signatures older
+	// than this are rejected. Use 0 for no tolerance check.
+	Tolerance time.Duration
+	// AcceptedSchemes lists the signature schemes to try, in order.
+	// Defaults to {"v1"} if empty.
+	AcceptedSchemes []string
+	// RequireKeyID rejects signature headers that carry no kid= component.
+	RequireKeyID bool
+	// Method and Path are the HTTP method and path of the webhook
+	// delivery request. Required when AcceptedSchemes includes "v2".
+	Method string
+	Path   string
+}
+
+// VerifyWebhookSignatureMulti verifies a webhook signature header that may
+// carry several concurrently valid scheme tokens (e.g.
+// "t=...,kid=key_2024_11,v1=...,v2=..."), trying each scheme in
+// opts.AcceptedSchemes against the secret registered for the header's key
+// ID. secrets maps a key ID to its signing secret; the empty key ID is the
+// default secret used when the header carries no kid= component.
+//
+// It returns the key ID and scheme that matched, so callers can log
+// rotation progress (e.g. alert when deliveries are still arriving signed
+// with a key scheduled for retirement).
+func VerifyWebhookSignatureMulti(payload, signature string, secrets map[string]string, opts VerifyOptions) (keyID, scheme string, err error) {
+	return verifySignatureMulti(payload, "", signature, secrets, opts)
+}
+
+// VerifyWebhookSignatureMultiStream verifies a v2-scheme signature using a
+// precomputed, hex-encoded SHA-256 hash of the body (as produced by
+// HashWebhookBody) instead of the raw payload. This lets a webhook handler
+// verify large deliveries without buffering the body twice. Accepting "v1"
+// in opts.AcceptedSchemes is an error here, since v1 requires the raw body.
+func VerifyWebhookSignatureMultiStream(bodyHashHex, signature string, secrets map[string]string, opts VerifyOptions) (keyID, scheme string, err error) {
+	schemes := opts.AcceptedSchemes
+	if len(schemes) == 0 {
+		schemes = []string{SchemeV1}
+	}
+	for _, s := range schemes {
+		if s == SchemeV1 {
+			return "", "", fmt.Errorf("escalation: v1 verification requires the raw payload; use VerifyWebhookSignatureMulti")
+		}
+	}
+	return verifySignatureMulti("", bodyHashHex, signature, secrets, opts)
+}
+
+// HashWebhookBody streams body to forward while computing its SHA-256
+// digest, returning the hex-encoded hash for use with
+// VerifyWebhookSignatureMultiStream. This lets a webhook handler verify a
+// v2-scheme signature on a large payload without buffering it twice to
+// compute the HMAC.
+func HashWebhookBody(body io.Reader, forward io.Writer) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h, forward), body); err != nil {
+		return "", fmt.Errorf("escalation: failed to hash webhook body: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func verifySignatureMulti(payload, bodyHashHex, signature string, secrets map[string]string, opts VerifyOptions) (string, string, error) {
+	if signature == "" {
+		return "", "", fmt.Errorf("escalation: empty signature header")
+	}
+
+	components := map[string]string{}
+	for _, part := range strings.Split(signature, ",") {
+		if eq := strings.IndexByte(part, '='); eq > 0 {
+			components[part[:eq]] = part[eq+1:]
+		}
+	}
+
+	timestampStr, ok := components["t"]
+	if !ok {
+		return "", "", fmt.Errorf("escalation: signature header missing t= timestamp")
+	}
+	timestampNum, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("escalation: invalid timestamp in signature header: %w", err)
+	}
+
+	if opts.Tolerance > 0 {
+		age := math.Abs(float64(time.Now().Unix() - timestampNum))
+		if age > opts.Tolerance.Seconds() {
+			return "", "", fmt.Errorf("escalation: signature timestamp outside tolerance (age %.0fs)", age)
+		}
+	}
+
+	keyID := components["kid"]
+	if opts.RequireKeyID && keyID == "" {
+		return "", "", fmt.Errorf("escalation: signature header missing required kid=")
+	}
+
+	secret, ok := secrets[keyID]
+	if !ok {
+		return "", "", fmt.Errorf("escalation: no secret registered for key id %q", keyID)
+	}
+
+	schemes := opts.AcceptedSchemes
+	if len(schemes) == 0 {
+		schemes = []string{SchemeV1}
+	}
+
+	for _, scheme := range schemes {
+		token, ok := components[scheme]
+		if !ok {
+			continue
+		}
+
+		var signedPayload string
+		switch scheme {
+		case SchemeV1:
+			if payload == "" {
+				return "", "", fmt.Errorf("escalation: v1 verification requires the raw payload; use VerifyWebhookSignatureMulti")
+			}
+			signedPayload = fmt.Sprintf("%s.%s", timestampStr, payload)
+		case SchemeV2:
+			hash := bodyHashHex
+			if hash == "" {
+				sum := sha256.Sum256([]byte(payload))
+				hash = hex.EncodeToString(sum[:])
+			}
+			signedPayload = fmt.Sprintf("%s.%s.%s.%s", timestampStr, hash, opts.Method, opts.Path)
+		default:
+			continue
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(signedPayload))
+		computed := mac.Sum(nil)
+
+		expected, err := hex.DecodeString(token)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(computed, expected) {
+			return keyID, scheme, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("escalation: no accepted scheme in the signature header matched")
+}
+
+// ConstructWebhookSignatureMulti builds a signature header carrying one
+// token per scheme in schemes, all signed with secret and tagged with
+// keyID (omitted from the header if empty). Used for generating test
+// fixtures and exercising server-side rotation; do NOT use this in
+// production.
+func ConstructWebhookSignatureMulti(payload, keyID, secret string, schemes []string, timestamp int64, method, path string) string {
+	if timestamp == 0 {
+		timestamp = time.Now().Unix()
+	}
+
+	parts := []string{fmt.Sprintf("t=%d", timestamp)}
+	if keyID != "" {
+		parts = append(parts, fmt.Sprintf("kid=%s", keyID))
+	}
+
+	for _, scheme := range schemes {
+		var signedPayload string
+		switch scheme {
+		case SchemeV2:
+			sum := sha256.Sum256([]byte(payload))
+			signedPayload = fmt.Sprintf("%d.%s.%s.%s", timestamp, hex.EncodeToString(sum[:]), method, path)
+		default: // v1
+			signedPayload = fmt.Sprintf("%d.%s", timestamp, payload)
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(signedPayload))
+		parts = append(parts, fmt.Sprintf("%s=%s", scheme, hex.EncodeToString(mac.Sum(nil))))
+	}
+
+	return strings.Join(parts, ",")
+}
+
 // ConstructWebhookSignature creates a webhook signature for testing purposes.
 // Do NOT use this in production.
 //